@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/common/dbutils"
+	"github.com/ledgerwatch/erigon/migrations"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	withDatadir(cmdMigrationsList)
+	migrationsCmd.AddCommand(cmdMigrationsList)
+
+	withDatadir(cmdMigrationsRollback)
+	cmdMigrationsRollback.Flags().String("to", "", "name of the migration to roll back to (exclusive); empty rolls back everything applied")
+	migrationsCmd.AddCommand(cmdMigrationsRollback)
+
+	rootCmd.AddCommand(migrationsCmd)
+}
+
+var migrationsCmd = &cobra.Command{
+	Use:   "migrations",
+	Short: "Inspect and manage DB migrations",
+}
+
+var cmdMigrationsList = &cobra.Command{
+	Use:   "list",
+	Short: "List migrations with their applied/pending/in-progress status",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db := openDatabase(chaindata, true)
+		defer db.Close()
+
+		statuses, err := migrations.NewMigrator().Status(db)
+		if err != nil {
+			return err
+		}
+
+		for _, st := range statuses {
+			switch {
+			case st.Applied:
+				fmt.Printf("%-40s applied    at=%s payload=%dB\n", st.Name, st.AppliedAt.Format("2006-01-02T15:04:05Z07:00"), st.PayloadSize)
+			case st.InProgress:
+				fmt.Printf("%-40s mid-flight progress_key=%x\n", st.Name, st.ProgressKey)
+			default:
+				fmt.Printf("%-40s pending\n", st.Name)
+			}
+		}
+
+		version, err := db.GetOne(dbutils.DatabaseInfoBucket, dbutils.DBSchemaVersionKey)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("schema version: %x\n", version)
+		return nil
+	},
+}
+
+var cmdMigrationsRollback = &cobra.Command{
+	Use:   "rollback",
+	Short: "Roll back applied migrations down to (but not including) --to",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, err := cmd.Flags().GetString("to")
+		if err != nil {
+			return err
+		}
+
+		db := openDatabase(chaindata, true)
+		defer db.Close()
+
+		migrator := migrations.NewMigrator()
+		return migrator.Rollback(db, datadir, target, mdbx)
+	},
+}
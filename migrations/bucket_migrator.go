@@ -0,0 +1,183 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/etl"
+	"github.com/ledgerwatch/erigon/ethdb"
+)
+
+// KeyFilter decides whether a source key should be migrated at all.
+type KeyFilter func(k []byte) (bool, error)
+
+// KeyValueTransform maps a source key/value pair to the key/value written
+// into the target bucket.
+type KeyValueTransform func(k, v []byte) ([]byte, []byte, error)
+
+// errBatchDone stops a Walk once BucketMigrator's batch size has been
+// reached, so Up can checkpoint between db.Walk calls rather than while one
+// is still iterating.
+var errBatchDone = errors.New("bucket migrator: batch done")
+
+// BucketMigrator builds a Migration.Up for the common case of copying (and
+// optionally filtering/transforming) one bucket into another. A single Up
+// call drives the source bucket to completion itself — Apply calls Up
+// exactly once per migration and never re-invokes it just because it
+// returned without a final isDone=true commit, so Up loops internally,
+// running one db.Walk per batch of up to BatchSize entries, checkpointing
+// via OnLoadCommit(key, false) once each Walk call has returned (never
+// while its cursor is still open), and finishing with a single
+// OnLoadCommit(nil, true) once the source is exhausted. It shares
+// CheckCancelled's cancellation semantics with hand-written migrations, so
+// an interrupt checkpoints the in-flight batch instead of losing it.
+//
+// Migrations that only need to drop a bucket outright, like
+// removeCliqueBucket, don't iterate anything and so get no benefit from
+// this helper; it's meant for migrations that actually move data between
+// buckets.
+//
+// No migration in this tree wires BucketMigrator in yet — it currently
+// ships unused by any registered Migration, exercised only by this
+// package's tests, pending a future bucket-copy migration that needs it.
+type BucketMigrator struct {
+	sourceBucket string
+	targetBucket string
+	batchSize    int
+	keyFilter    KeyFilter
+	transform    KeyValueTransform
+	before       func(db ethdb.Database) error
+}
+
+// NewBucketMigrator returns a BucketMigrator copying sourceBucket into
+// targetBucket in batches of batchSize.
+func NewBucketMigrator(sourceBucket, targetBucket string, batchSize int) *BucketMigrator {
+	return &BucketMigrator{
+		sourceBucket: sourceBucket,
+		targetBucket: targetBucket,
+		batchSize:    batchSize,
+	}
+}
+
+// WithKeyFilter skips source keys for which f returns false.
+func (b *BucketMigrator) WithKeyFilter(f KeyFilter) *BucketMigrator {
+	b.keyFilter = f
+	return b
+}
+
+// WithTransform rewrites a source key/value pair before it's written to
+// the target bucket. Without one, the source key/value are copied as-is.
+func (b *BucketMigrator) WithTransform(f KeyValueTransform) *BucketMigrator {
+	b.transform = f
+	return b
+}
+
+// WithBefore runs f once, before the first batch of a fresh (non-resumed)
+// run, e.g. to clear a partially-written target bucket from a prior
+// incompatible migration attempt.
+func (b *BucketMigrator) WithBefore(f func(db ethdb.Database) error) *BucketMigrator {
+	b.before = f
+	return b
+}
+
+// Up builds the Migration.Up function for this BucketMigrator.
+func (b *BucketMigrator) Up() func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+	return func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+		if len(progress) == 0 && b.before != nil {
+			if err := b.before(db); err != nil {
+				return err
+			}
+		}
+
+		var counter uint64
+		for {
+			var lastKey []byte
+			processed := 0
+			walkErr := db.Walk(b.sourceBucket, progress, 0, func(k, v []byte) (bool, error) {
+				if len(progress) > 0 && bytes.Equal(k, progress) {
+					return true, nil // progress is the last migrated key, resume after it
+				}
+
+				counter++
+				if err := CheckCancelled(ctx, counter); err != nil {
+					return false, err
+				}
+
+				if b.keyFilter != nil {
+					ok, err := b.keyFilter(k)
+					if err != nil {
+						return false, err
+					}
+					if !ok {
+						return true, nil
+					}
+				}
+
+				targetKey, targetValue := k, v
+				if b.transform != nil {
+					var err error
+					targetKey, targetValue, err = b.transform(k, v)
+					if err != nil {
+						return false, err
+					}
+				}
+				if err := db.Put(b.targetBucket, common.CopyBytes(targetKey), common.CopyBytes(targetValue)); err != nil {
+					return false, err
+				}
+
+				lastKey = common.CopyBytes(k)
+				processed++
+				if b.batchSize > 0 && processed >= b.batchSize {
+					return false, errBatchDone
+				}
+				return true, nil
+			})
+
+			switch {
+			case errors.Is(walkErr, ErrMigrationCancelled):
+				if err := OnLoadCommit(db, lastKey, false); err != nil {
+					return err
+				}
+				return ErrMigrationCancelled
+			case errors.Is(walkErr, errBatchDone):
+				more, err := b.hasMoreAfter(db, lastKey)
+				if err != nil {
+					return err
+				}
+				if !more {
+					return OnLoadCommit(db, nil, true)
+				}
+				if err := OnLoadCommit(db, lastKey, false); err != nil {
+					return err
+				}
+				progress = lastKey
+				continue
+			case walkErr != nil:
+				return walkErr
+			default:
+				return OnLoadCommit(db, nil, true)
+			}
+		}
+	}
+}
+
+// hasMoreAfter reports whether the source bucket has any key past key, so
+// Up can tell a batch that merely hit BatchSize apart from one that also
+// happened to consume the last key in the bucket — the latter finishes the
+// migration immediately rather than running one more, empty, batch just to
+// discover exhaustion. The Walk it issues always completes (or errors)
+// before Up acts on its result, so it never overlaps with the checkpoint
+// commit that follows.
+func (b *BucketMigrator) hasMoreAfter(db ethdb.Database, key []byte) (bool, error) {
+	more := false
+	err := db.Walk(b.sourceBucket, key, 0, func(k, v []byte) (bool, error) {
+		if bytes.Equal(k, key) {
+			return true, nil
+		}
+		more = true
+		return false, nil
+	})
+	return more, err
+}
@@ -0,0 +1,115 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ledgerwatch/erigon/common/dbutils"
+	"github.com/ledgerwatch/erigon/common/debug"
+	"github.com/ledgerwatch/erigon/ethdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucketMigratorCopiesFilteredAndTransformed(t *testing.T) {
+	require, db := require.New(t), ethdb.NewTestDB(t)
+
+	require.NoError(db.Put(dbutils.SyncStageProgress, []byte("keep-1"), []byte("a")))
+	require.NoError(db.Put(dbutils.SyncStageProgress, []byte("skip-1"), []byte("b")))
+	require.NoError(db.Put(dbutils.SyncStageProgress, []byte("keep-2"), []byte("c")))
+
+	up := NewBucketMigrator(string(dbutils.SyncStageProgress), string(dbutils.CliqueBucket), 0).
+		WithKeyFilter(func(k []byte) (bool, error) {
+			return string(k) != "skip-1", nil
+		}).
+		WithTransform(func(k, v []byte) ([]byte, []byte, error) {
+			return k, append(v, '!'), nil
+		}).
+		Up()
+
+	var committed bool
+	err := up(context.Background(), db, "", nil, func(_ ethdb.Putter, key []byte, isDone bool) error {
+		require.True(isDone)
+		committed = true
+		return nil
+	})
+	require.NoError(err)
+	require.True(committed)
+
+	v, err := db.GetOne(dbutils.CliqueBucket, []byte("keep-1"))
+	require.NoError(err)
+	require.Equal([]byte("a!"), v)
+
+	v, err = db.GetOne(dbutils.CliqueBucket, []byte("keep-2"))
+	require.NoError(err)
+	require.Equal([]byte("c!"), v)
+
+	v, err = db.GetOne(dbutils.CliqueBucket, []byte("skip-1"))
+	require.NoError(err)
+	require.Nil(v)
+}
+
+func TestBucketMigratorChecksPointsWithinASingleCall(t *testing.T) {
+	require, db := require.New(t), ethdb.NewTestDB(t)
+
+	require.NoError(db.Put(dbutils.SyncStageProgress, []byte("k1"), []byte("v1")))
+	require.NoError(db.Put(dbutils.SyncStageProgress, []byte("k2"), []byte("v2")))
+	require.NoError(db.Put(dbutils.SyncStageProgress, []byte("k3"), []byte("v3")))
+
+	up := NewBucketMigrator(string(dbutils.SyncStageProgress), string(dbutils.CliqueBucket), 1).Up()
+
+	type commit struct {
+		key    []byte
+		isDone bool
+	}
+	var commits []commit
+	onLoadCommit := func(_ ethdb.Putter, key []byte, isDone bool) error {
+		commits = append(commits, commit{key, isDone})
+		return nil
+	}
+
+	// Apply calls Up exactly once per migration, so a source bucket larger
+	// than BatchSize must be fully migrated within that one call, with
+	// OnLoadCommit(key, false) checkpointing every batch along the way and
+	// a single OnLoadCommit(nil, true) at the end.
+	require.NoError(up(context.Background(), db, "", nil, onLoadCommit))
+
+	require.Equal([]commit{
+		{[]byte("k1"), false},
+		{[]byte("k2"), false},
+		{nil, true},
+	}, commits)
+
+	for _, k := range []string{"k1", "k2", "k3"} {
+		v, err := db.GetOne(dbutils.CliqueBucket, []byte(k))
+		require.NoError(err)
+		require.NotNil(v)
+	}
+}
+
+func TestBucketMigratorAppliesThroughMigrator(t *testing.T) {
+	require, db := require.New(t), ethdb.NewTestDB(t)
+
+	require.NoError(db.Put(dbutils.SyncStageProgress, []byte("k1"), []byte("v1")))
+	require.NoError(db.Put(dbutils.SyncStageProgress, []byte("k2"), []byte("v2")))
+	require.NoError(db.Put(dbutils.SyncStageProgress, []byte("k3"), []byte("v3")))
+
+	copyBucket := Migration{
+		Name: "copy_sync_stage_progress",
+		Up:   NewBucketMigrator(string(dbutils.SyncStageProgress), string(dbutils.CliqueBucket), 1).Up(),
+	}
+
+	migrator := NewMigrator()
+	migrator.Migrations = []Migration{copyBucket}
+	require.NoError(migrator.Apply(context.Background(), db, "", debug.TestDB() == "mdbx"))
+
+	for _, k := range []string{"k1", "k2", "k3"} {
+		v, err := db.GetOne(dbutils.CliqueBucket, []byte(k))
+		require.NoError(err)
+		require.NotNil(v)
+	}
+
+	applied, err := AppliedMigrations(db, false)
+	require.NoError(err)
+	_, ok := applied[copyBucket.Name]
+	require.True(ok)
+}
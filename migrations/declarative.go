@@ -0,0 +1,193 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/etl"
+	"github.com/ledgerwatch/erigon/ethdb"
+)
+
+// StatementOp enumerates the schema operations a declarative migration can
+// express without hand-written Go: everything expressible as bucket
+// create/drop/rename and key-range deletes.
+type StatementOp int
+
+const (
+	CreateBucket StatementOp = iota
+	DropBucket
+	RenameBucket
+	DeleteRange
+	CopyBucket
+)
+
+// Statement is one step of a declarative migration. Bucket is the primary
+// operand (the bucket created/dropped/copied/renamed, or the bucket a
+// DeleteRange runs against); To is the rename/copy destination bucket;
+// Start/End bound a DeleteRange (End is exclusive, nil means "to the end").
+type Statement struct {
+	Op     StatementOp
+	Bucket string
+	To     string
+	Start  []byte
+	End    []byte
+}
+
+// StatementExecutor carries out a single Statement against db. It exists so
+// declarative migrations aren't hard-wired to one storage engine's bucket
+// API.
+type StatementExecutor interface {
+	CreateBucket(db ethdb.Database, bucket string) error
+	DropBucket(db ethdb.Database, bucket string) error
+	RenameBucket(db ethdb.Database, from, to string) error
+	DeleteRange(db ethdb.Database, bucket string, start, end []byte) error
+	CopyBucket(db ethdb.Database, from, to string) error
+}
+
+// DefaultStatementExecutor is the StatementExecutor RegisterDeclarative uses
+// unless told otherwise; it operates through ethdb.BucketsMigrator and
+// db.Walk/Put/Delete, the same primitives hand-written migrations use.
+var DefaultStatementExecutor StatementExecutor = defaultStatementExecutor{}
+
+type defaultStatementExecutor struct{}
+
+// CreateBucket creates bucket if it doesn't already exist. It never clears
+// an existing bucket, so a re-applied or partially-applied migration can't
+// destroy data already sitting in it; migrations that do need a clean
+// bucket should DropBucket it first or use CopyBucket into a fresh name.
+func (defaultStatementExecutor) CreateBucket(db ethdb.Database, bucket string) error {
+	migrator := db.(ethdb.BucketsMigrator)
+	exists, err := migrator.BucketExists(bucket)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	return migrator.ClearBuckets(bucket)
+}
+
+func (defaultStatementExecutor) DropBucket(db ethdb.Database, bucket string) error {
+	migrator := db.(ethdb.BucketsMigrator)
+	exists, err := migrator.BucketExists(bucket)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+	return migrator.DropBuckets(bucket)
+}
+
+func (d defaultStatementExecutor) RenameBucket(db ethdb.Database, from, to string) error {
+	if err := d.CopyBucket(db, from, to); err != nil {
+		return err
+	}
+	return d.DropBucket(db, from)
+}
+
+func (defaultStatementExecutor) DeleteRange(db ethdb.Database, bucket string, start, end []byte) error {
+	var toDelete [][]byte
+	if err := db.Walk(bucket, start, 0, func(k, v []byte) (bool, error) {
+		if end != nil && bytes.Compare(k, end) >= 0 {
+			return false, nil
+		}
+		toDelete = append(toDelete, common.CopyBytes(k))
+		return true, nil
+	}); err != nil {
+		return err
+	}
+	for _, k := range toDelete {
+		if err := db.Delete(bucket, k, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (defaultStatementExecutor) CopyBucket(db ethdb.Database, from, to string) error {
+	return db.Walk(from, nil, 0, func(k, v []byte) (bool, error) {
+		if err := db.Put(to, common.CopyBytes(k), common.CopyBytes(v)); err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+}
+
+func execStatement(db ethdb.Database, exec StatementExecutor, s Statement) error {
+	switch s.Op {
+	case CreateBucket:
+		return exec.CreateBucket(db, s.Bucket)
+	case DropBucket:
+		return exec.DropBucket(db, s.Bucket)
+	case RenameBucket:
+		return exec.RenameBucket(db, s.Bucket, s.To)
+	case DeleteRange:
+		return exec.DeleteRange(db, s.Bucket, s.Start, s.End)
+	case CopyBucket:
+		return exec.CopyBucket(db, s.Bucket, s.To)
+	default:
+		return fmt.Errorf("migrations: unknown declarative statement op %d", s.Op)
+	}
+}
+
+// runStatements executes statements in order, checkpointing the index of
+// the next statement to run via OnLoadCommit after each one so a crash or
+// cancellation resumes from the right step instead of re-running
+// already-applied statements.
+func runStatements(ctx context.Context, db ethdb.Database, exec StatementExecutor, statements []Statement, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+	start := 0
+	if len(progress) == 8 {
+		start = int(binary.BigEndian.Uint64(progress))
+	}
+
+	for i := start; i < len(statements); i++ {
+		if err := CheckCancelled(ctx, uint64(i)); err != nil {
+			var cursor [8]byte
+			binary.BigEndian.PutUint64(cursor[:], uint64(i))
+			if ckErr := OnLoadCommit(db, cursor[:], false); ckErr != nil {
+				return ckErr
+			}
+			return err
+		}
+		if err := execStatement(db, exec, statements[i]); err != nil {
+			return err
+		}
+		var cursor [8]byte
+		binary.BigEndian.PutUint64(cursor[:], uint64(i+1))
+		if err := OnLoadCommit(db, cursor[:], false); err != nil {
+			return err
+		}
+	}
+	return OnLoadCommit(db, nil, true)
+}
+
+// RegisterDeclarative builds a Migration from ordered up/down statement
+// lists instead of hand-written Up/Down funcs, for schema changes that are
+// just bucket create/drop/rename or key-range deletes. down may be nil, in
+// which case the migration is irreversible, same as a Migration with no
+// Down. It's executed through the same _progress_ checkpoint machinery as
+// Go migrations, so put the result directly into the migrations slice:
+//
+//	var removeFooBucket = RegisterDeclarative(
+//		"remove_foo_bucket",
+//		[]Statement{{Op: DropBucket, Bucket: string(dbutils.FooBucket)}},
+//		nil,
+//	)
+func RegisterDeclarative(name string, up, down []Statement) Migration {
+	m := Migration{
+		Name: name,
+		Up: func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+			return runStatements(ctx, db, DefaultStatementExecutor, up, progress, OnLoadCommit)
+		},
+	}
+	if down != nil {
+		m.Down = func(db ethdb.Database, tmpdir string, payload []byte, OnLoadCommit etl.LoadCommitHandler) error {
+			return runStatements(context.Background(), db, DefaultStatementExecutor, down, nil, OnLoadCommit)
+		}
+	}
+	return m
+}
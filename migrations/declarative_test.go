@@ -0,0 +1,99 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ledgerwatch/erigon/common/dbutils"
+	"github.com/ledgerwatch/erigon/common/debug"
+	"github.com/ledgerwatch/erigon/ethdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterDeclarativeCopyAndDrop(t *testing.T) {
+	require, db := require.New(t), ethdb.NewTestDB(t)
+
+	require.NoError(db.Put(dbutils.SyncStageProgress, []byte("k1"), []byte("v1")))
+	require.NoError(db.Put(dbutils.SyncStageProgress, []byte("k2"), []byte("v2")))
+
+	copyThenDrop := RegisterDeclarative(
+		"copy_then_drop",
+		[]Statement{
+			{Op: CopyBucket, Bucket: string(dbutils.SyncStageProgress), To: string(dbutils.CliqueBucket)},
+			{Op: DropBucket, Bucket: string(dbutils.SyncStageProgress)},
+		},
+		nil,
+	)
+
+	migrator := NewMigrator()
+	migrator.Migrations = []Migration{copyThenDrop}
+	require.NoError(migrator.Apply(context.Background(), db, "", debug.TestDB() == "mdbx"))
+
+	v, err := db.GetOne(dbutils.CliqueBucket, []byte("k1"))
+	require.NoError(err)
+	require.Equal([]byte("v1"), v)
+
+	exists, err := db.(ethdb.BucketsMigrator).BucketExists(dbutils.SyncStageProgress)
+	require.NoError(err)
+	require.False(exists)
+}
+
+func TestCreateBucketDoesNotClearExistingData(t *testing.T) {
+	require, db := require.New(t), ethdb.NewTestDB(t)
+
+	require.NoError(db.Put(dbutils.CliqueBucket, []byte("k1"), []byte("v1")))
+
+	createBucket := RegisterDeclarative(
+		"create_clique_bucket",
+		[]Statement{{Op: CreateBucket, Bucket: string(dbutils.CliqueBucket)}},
+		nil,
+	)
+
+	migrator := NewMigrator()
+	migrator.Migrations = []Migration{createBucket}
+	require.NoError(migrator.Apply(context.Background(), db, "", debug.TestDB() == "mdbx"))
+
+	v, err := db.GetOne(dbutils.CliqueBucket, []byte("k1"))
+	require.NoError(err)
+	require.Equal([]byte("v1"), v)
+}
+
+func TestRegisterDeclarativeIsIdempotent(t *testing.T) {
+	require, db := require.New(t), ethdb.NewTestDB(t)
+
+	migrator := NewMigrator()
+	migrator.Migrations = []Migration{removeCliqueBucket}
+	require.NoError(migrator.Apply(context.Background(), db, "", debug.TestDB() == "mdbx"))
+	require.NoError(migrator.Apply(context.Background(), db, "", debug.TestDB() == "mdbx"))
+
+	applied, err := AppliedMigrations(db, false)
+	require.NoError(err)
+	_, ok := applied[removeCliqueBucket.Name]
+	require.True(ok)
+}
+
+func TestRegisterDeclarativeRollback(t *testing.T) {
+	require, db := require.New(t), ethdb.NewTestDB(t)
+
+	require.NoError(db.Put(dbutils.SyncStageProgress, []byte("k1"), []byte("v1")))
+
+	renamed := RegisterDeclarative(
+		"rename_sync_stage_progress",
+		[]Statement{{Op: RenameBucket, Bucket: string(dbutils.SyncStageProgress), To: string(dbutils.CliqueBucket)}},
+		[]Statement{{Op: RenameBucket, Bucket: string(dbutils.CliqueBucket), To: string(dbutils.SyncStageProgress)}},
+	)
+
+	migrator := NewMigrator()
+	migrator.Migrations = []Migration{renamed}
+	require.NoError(migrator.Apply(context.Background(), db, "", debug.TestDB() == "mdbx"))
+
+	v, err := db.GetOne(dbutils.CliqueBucket, []byte("k1"))
+	require.NoError(err)
+	require.Equal([]byte("v1"), v)
+
+	require.NoError(migrator.Rollback(db, "", "", debug.TestDB() == "mdbx"))
+
+	v, err = db.GetOne(dbutils.SyncStageProgress, []byte("k1"))
+	require.NoError(err)
+	require.Equal([]byte("v1"), v)
+}
@@ -5,7 +5,11 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
+	"os"
+	"os/signal"
 	"path"
+	"syscall"
+	"time"
 
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/dbutils"
@@ -30,7 +34,7 @@ import (
 // - in the beginning of migration: check that old bucket exists, clear new bucket
 // - in the end:drop old bucket (not in defer!).
 //	Example:
-//	Up: func(db ethdb.Database, tmpdir string, OnLoadCommit etl.LoadCommitHandler) error {
+//	Up: func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
 //		if exists, err := db.(ethdb.BucketsMigrator).BucketExists(dbutils.SyncStageProgressOld1); err != nil {
 //			return err
 //		} else if !exists {
@@ -62,15 +66,46 @@ var migrations = []Migration{
 
 type Migration struct {
 	Name string
-	Up   func(db ethdb.Database, tmpdir string, progress []byte, OnLoadCommitOnLoadCommit etl.LoadCommitHandler) error
+	Up   func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error
+	// Down reverses Up, given the CBOR stage-progress payload that was
+	// recorded for this migration when it applied (see
+	// MarshalMigrationPayload). Migrations that don't set Down are
+	// irreversible: Rollback refuses to run past them.
+	Down func(db ethdb.Database, tmpdir string, payload []byte, OnLoadCommit etl.LoadCommitHandler) error
 }
 
 var (
 	ErrMigrationNonUniqueName   = fmt.Errorf("please provide unique migration name")
 	ErrMigrationCommitNotCalled = fmt.Errorf("migration commit function was not called")
 	ErrMigrationETLFilesDeleted = fmt.Errorf("db migration progress was interrupted after extraction step and ETL files was deleted, please contact development team for help or re-sync from scratch")
+	ErrMigrationCancelled       = fmt.Errorf("migration was cancelled, progress has been checkpointed and will resume on next startup")
+	ErrMigrationIrreversible    = fmt.Errorf("migration has no Down and cannot be rolled back")
+	ErrMigrationNotFound        = fmt.Errorf("target migration not found")
 )
 
+// migrationCancelCheckEvery bounds how often a bucket-iterating migration
+// checks its context for cancellation. Checking on every key would add a
+// channel-select per key for migrations iterating billions of keys.
+const migrationCancelCheckEvery = 1024
+
+// CheckCancelled returns ErrMigrationCancelled once ctx has been cancelled,
+// but only samples ctx.Done() every migrationCancelCheckEvery calls (keyed
+// off the caller's iteration counter). Long-running bucket migrations call
+// this from inside their iteration loop, and on a non-nil result they must
+// checkpoint the current key via OnLoadCommit(db, key, false) before
+// returning the error, so Apply can resume from exactly that point.
+func CheckCancelled(ctx context.Context, counter uint64) error {
+	if counter%migrationCancelCheckEvery != 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ErrMigrationCancelled
+	default:
+		return nil
+	}
+}
+
 func NewMigrator() *Migrator {
 	return &Migrator{
 		Migrations: migrations,
@@ -131,11 +166,76 @@ func (m *Migrator) PendingMigrations(db ethdb.Database) ([]Migration, error) {
 	return pending, nil
 }
 
-func (m *Migrator) Apply(db ethdb.Database, datadir string, mdbx bool) error {
+// MigrationStatus describes one registered migration's state, for tooling
+// like `erigon migrations list` that needs to show what has run, what is
+// pending, and what got interrupted mid-flight.
+type MigrationStatus struct {
+	Name        string
+	Applied     bool
+	AppliedAt   time.Time
+	InProgress  bool
+	ProgressKey []byte
+	PayloadSize int
+}
+
+// Status reports, for every registered migration in order, whether it has
+// applied (and when), or is mid-flight with a checkpointed progress cursor.
+func (m *Migrator) Status(db ethdb.Database) ([]MigrationStatus, error) {
+	applied, err := AppliedMigrations(db, true)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.Migrations))
+	for i := range m.Migrations {
+		v := m.Migrations[i]
+		st := MigrationStatus{Name: v.Name}
+
+		if value, ok := applied[v.Name]; ok {
+			st.Applied = true
+			appliedAt, payload := splitMigrationValue(value)
+			st.AppliedAt = appliedAt
+			st.PayloadSize = len(payload)
+		} else {
+			progress, err := db.GetOne(dbutils.Migrations, []byte("_progress_"+v.Name))
+			if err != nil {
+				return nil, err
+			}
+			if len(progress) > 0 {
+				st.InProgress = true
+				st.ProgressKey = common.CopyBytes(progress)
+			}
+		}
+
+		statuses = append(statuses, st)
+	}
+	return statuses, nil
+}
+
+// Apply runs all pending migrations in order. ctx is threaded down to each
+// Migration.Up so long-running migrations can cooperatively cancel on
+// SIGINT/SIGTERM: Apply installs a signal handler that cancels ctx, and a
+// migration that observes cancellation is expected to checkpoint its
+// progress via OnLoadCommit(db, key, false) and return ErrMigrationCancelled
+// so it resumes from that key on the next startup.
+func (m *Migrator) Apply(ctx context.Context, db ethdb.Database, datadir string, mdbx bool) error {
 	if len(m.Migrations) == 0 {
 		return nil
 	}
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigc)
+	go func() {
+		select {
+		case <-sigc:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
 	applied, err1 := AppliedMigrations(db, false)
 	if err1 != nil {
 		return err1
@@ -171,7 +271,7 @@ func (m *Migrator) Apply(db ethdb.Database, datadir string, mdbx bool) error {
 			return err
 		}
 
-		if err = v.Up(tx, path.Join(datadir, "migrations", v.Name), progress, func(_ ethdb.Putter, key []byte, isDone bool) error {
+		if err = v.Up(ctx, tx, path.Join(datadir, "migrations", v.Name), progress, func(_ ethdb.Putter, key []byte, isDone bool) error {
 			if !isDone {
 				if key != nil {
 					err = tx.Put(dbutils.Migrations, []byte("_progress_"+v.Name), key)
@@ -191,7 +291,7 @@ func (m *Migrator) Apply(db ethdb.Database, datadir string, mdbx bool) error {
 			if err != nil {
 				return err
 			}
-			err = tx.Put(dbutils.Migrations, []byte(v.Name), stagesProgress)
+			err = tx.Put(dbutils.Migrations, []byte(v.Name), appendTimestamp(stagesProgress))
 			if err != nil {
 				return err
 			}
@@ -239,6 +339,97 @@ func (m *Migrator) Apply(db ethdb.Database, datadir string, mdbx bool) error {
 	return nil
 }
 
+// Rollback undoes applied migrations in reverse order, down to (but not
+// including) targetMigration, by invoking each migration's Down with the
+// payload it stored when it applied. targetMigration == "" rolls back
+// every applied migration. It refuses to roll back anything if any
+// migration in the affected range has no Down, reporting all of them at
+// once so the operator knows exactly what blocks the rollback, rather
+// than leaving the DB half rolled-back.
+func (m *Migrator) Rollback(db ethdb.Database, datadir string, targetMigration string, mdbx bool) error {
+	applied, err := AppliedMigrations(db, true)
+	if err != nil {
+		return err
+	}
+
+	// targetMigration == "" rolls back every applied migration.
+	targetIdx := -1
+	lastAppliedIdx := -1
+	for i := range m.Migrations {
+		name := m.Migrations[i].Name
+		if name == targetMigration {
+			targetIdx = i
+		}
+		if _, ok := applied[name]; ok {
+			lastAppliedIdx = i
+		}
+	}
+	if targetMigration != "" && targetIdx == -1 {
+		return fmt.Errorf("%w: %s", ErrMigrationNotFound, targetMigration)
+	}
+	if lastAppliedIdx <= targetIdx {
+		return nil
+	}
+
+	// Only migrations that actually applied are rolled back. Apply allows a
+	// later migration to be recorded as applied while an earlier one in
+	// m.Migrations isn't (see TestWhenNonFirstMigrationAlreadyApplied), so
+	// the index range between target and the last applied migration isn't
+	// guaranteed to be a contiguous run of applied names; entries outside
+	// applied are left untouched rather than having Down run against them.
+	var toRollback []Migration
+	for i := lastAppliedIdx; i > targetIdx; i-- {
+		if _, ok := applied[m.Migrations[i].Name]; ok {
+			toRollback = append(toRollback, m.Migrations[i])
+		}
+	}
+
+	var irreversible []string
+	for _, v := range toRollback {
+		if v.Down == nil {
+			irreversible = append(irreversible, v.Name)
+		}
+	}
+	if len(irreversible) > 0 {
+		return fmt.Errorf("%w: %v", ErrMigrationIrreversible, irreversible)
+	}
+
+	tx, err := db.Begin(context.Background(), ethdb.RW)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, v := range toRollback {
+		_, payload := splitMigrationValue(applied[v.Name])
+
+		log.Info("Rollback migration", "name", v.Name)
+		commitFuncCalled := false
+		if err := v.Down(tx, path.Join(datadir, "migrations", v.Name), payload, func(_ ethdb.Putter, _ []byte, isDone bool) error {
+			if !isDone {
+				return nil
+			}
+			commitFuncCalled = true
+			if err := tx.Delete(dbutils.Migrations, []byte(v.Name), nil); err != nil {
+				return err
+			}
+			return tx.Delete(dbutils.Migrations, []byte("_progress_"+v.Name), nil)
+		}); err != nil {
+			return err
+		}
+		if !commitFuncCalled {
+			return fmt.Errorf("%w: %s", ErrMigrationCommitNotCalled, v.Name)
+		}
+		log.Info("Rolled back migration", "name", v.Name)
+	}
+
+	// Apply always (re)writes DBSchemaVersionKey as the absolute, current
+	// build's version rather than bumping it per migration, so there is no
+	// per-migration delta for Rollback to undo here; DBSchemaVersionKey is
+	// left as Apply last wrote it.
+	return tx.Commit()
+}
+
 func MarshalMigrationPayload(db ethdb.Getter) ([]byte, error) {
 	s := map[string][]byte{}
 
@@ -269,11 +460,56 @@ func MarshalMigrationPayload(db ethdb.Getter) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// migrationPayloadVersion1 marks a value stored at dbutils.Migrations[name]
+// as carrying the [1-byte version][8-byte unix timestamp] prefix added by
+// appendTimestamp, as opposed to the original bare-CBOR format written
+// before that prefix existed. A genuine CBOR encoding of map[string][]byte
+// always opens with a major-type-5 (map) header byte, in the 0xa0-0xbf
+// range, so this sentinel can never collide with a legacy payload.
+const migrationPayloadVersion1 = 0x01
+
+const migrationPayloadV1HeaderSize = 1 + 8 // version byte + unix timestamp
+
+// appendTimestamp prepends a migrationPayloadVersion1 header (version byte
+// + unix timestamp) to a migration's CBOR stage-progress payload before
+// it's stored at dbutils.Migrations[name], so Status can report when a
+// migration applied.
+func appendTimestamp(payload []byte) []byte {
+	out := make([]byte, migrationPayloadV1HeaderSize+len(payload))
+	out[0] = migrationPayloadVersion1
+	binary.BigEndian.PutUint64(out[1:migrationPayloadV1HeaderSize], uint64(time.Now().Unix()))
+	copy(out[migrationPayloadV1HeaderSize:], payload)
+	return out
+}
+
+// UnmarshalMigrationPayload decodes a value stored at
+// dbutils.Migrations[name] back into the stage-progress map written by
+// MarshalMigrationPayload. A value may carry the migrationPayloadVersion1
+// header added by appendTimestamp; that's detected by its explicit leading
+// version byte (not by guessing from whether the rest CBOR-decodes) and
+// stripped before decoding, so callers don't need to know which format a
+// given value was written in.
 func UnmarshalMigrationPayload(data []byte) (map[string][]byte, error) {
 	s := map[string][]byte{}
 
+	if len(data) >= migrationPayloadV1HeaderSize && data[0] == migrationPayloadVersion1 {
+		data = data[migrationPayloadV1HeaderSize:]
+	}
+
 	if err := codec.NewDecoder(bytes.NewReader(data), &codec.CborHandle{}).Decode(&s); err != nil {
 		return nil, err
 	}
 	return s, nil
 }
+
+// splitMigrationValue separates a value stored at dbutils.Migrations[name]
+// into its apply timestamp and CBOR payload, using the same explicit
+// migrationPayloadVersion1 marker UnmarshalMigrationPayload checks. Legacy
+// values (written before that marker existed) have no apply timestamp.
+func splitMigrationValue(v []byte) (appliedAt time.Time, payload []byte) {
+	if len(v) >= migrationPayloadV1HeaderSize && v[0] == migrationPayloadVersion1 {
+		ts := binary.BigEndian.Uint64(v[1:migrationPayloadV1HeaderSize])
+		return time.Unix(int64(ts), 0), v[migrationPayloadV1HeaderSize:]
+	}
+	return time.Time{}, v
+}
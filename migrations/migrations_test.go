@@ -1,6 +1,7 @@
 package migrations
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -17,14 +18,14 @@ func TestApplyWithInit(t *testing.T) {
 	require, db := require.New(t), ethdb.NewTestDB(t)
 	migrations = []Migration{
 		{
-			"one",
-			func(db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+			Name: "one",
+			Up: func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
 				return OnLoadCommit(db, nil, true)
 			},
 		},
 		{
-			"two",
-			func(db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+			Name: "two",
+			Up: func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
 				return OnLoadCommit(db, nil, true)
 			},
 		},
@@ -32,7 +33,7 @@ func TestApplyWithInit(t *testing.T) {
 
 	migrator := NewMigrator()
 	migrator.Migrations = migrations
-	err := migrator.Apply(db, "", debug.TestDB() == "mdbx")
+	err := migrator.Apply(context.Background(), db, "", debug.TestDB() == "mdbx")
 	require.NoError(err)
 
 	applied, err := AppliedMigrations(db, false)
@@ -44,7 +45,7 @@ func TestApplyWithInit(t *testing.T) {
 	require.True(ok)
 
 	// apply again
-	err = migrator.Apply(db, "", debug.TestDB() == "mdbx")
+	err = migrator.Apply(context.Background(), db, "", debug.TestDB() == "mdbx")
 	require.NoError(err)
 
 	applied2, err := AppliedMigrations(db, false)
@@ -56,15 +57,15 @@ func TestApplyWithoutInit(t *testing.T) {
 	require, db := require.New(t), ethdb.NewTestDB(t)
 	migrations = []Migration{
 		{
-			"one",
-			func(db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+			Name: "one",
+			Up: func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
 				t.Fatal("shouldn't been executed")
 				return nil
 			},
 		},
 		{
-			"two",
-			func(db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+			Name: "two",
+			Up: func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
 				return OnLoadCommit(db, nil, true)
 			},
 		},
@@ -74,7 +75,7 @@ func TestApplyWithoutInit(t *testing.T) {
 
 	migrator := NewMigrator()
 	migrator.Migrations = migrations
-	err = migrator.Apply(db, "", debug.TestDB() == "mdbx")
+	err = migrator.Apply(context.Background(), db, "", debug.TestDB() == "mdbx")
 	require.NoError(err)
 
 	applied, err := AppliedMigrations(db, false)
@@ -87,7 +88,7 @@ func TestApplyWithoutInit(t *testing.T) {
 	require.True(ok)
 
 	// apply again
-	err = migrator.Apply(db, "", debug.TestDB() == "mdbx")
+	err = migrator.Apply(context.Background(), db, "", debug.TestDB() == "mdbx")
 	require.NoError(err)
 
 	applied2, err := AppliedMigrations(db, false)
@@ -99,14 +100,14 @@ func TestWhenNonFirstMigrationAlreadyApplied(t *testing.T) {
 	require, db := require.New(t), ethdb.NewTestDB(t)
 	migrations = []Migration{
 		{
-			"one",
-			func(db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+			Name: "one",
+			Up: func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
 				return OnLoadCommit(db, nil, true)
 			},
 		},
 		{
-			"two",
-			func(db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+			Name: "two",
+			Up: func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
 				t.Fatal("shouldn't been executed")
 				return nil
 			},
@@ -117,7 +118,7 @@ func TestWhenNonFirstMigrationAlreadyApplied(t *testing.T) {
 
 	migrator := NewMigrator()
 	migrator.Migrations = migrations
-	err = migrator.Apply(db, "", debug.TestDB() == "mdbx")
+	err = migrator.Apply(context.Background(), db, "", debug.TestDB() == "mdbx")
 	require.NoError(err)
 
 	applied, err := AppliedMigrations(db, false)
@@ -130,7 +131,7 @@ func TestWhenNonFirstMigrationAlreadyApplied(t *testing.T) {
 	require.True(ok)
 
 	// apply again
-	err = migrator.Apply(db, "", debug.TestDB() == "mdbx")
+	err = migrator.Apply(context.Background(), db, "", debug.TestDB() == "mdbx")
 	require.NoError(err)
 
 	applied2, err := AppliedMigrations(db, false)
@@ -156,25 +157,100 @@ func TestMarshalStages(t *testing.T) {
 	require.NotNil(v)
 }
 
+func TestSplitMigrationValue(t *testing.T) {
+	require := require.New(t)
+
+	legacy := []byte("legacy-payload-that-is-not-cbor-at-all")
+	legacyAppliedAt, legacyPayload := splitMigrationValue(legacy)
+	require.True(legacyAppliedAt.IsZero())
+	require.Equal(legacy, legacyPayload)
+
+	cbor, err := MarshalMigrationPayload(ethdb.NewTestDB(t))
+	require.NoError(err)
+
+	// A legacy (unprefixed) real CBOR payload must still read as legacy:
+	// the marker is the explicit version byte, not whether it decodes.
+	cborAppliedAt, cborPayload := splitMigrationValue(cbor)
+	require.True(cborAppliedAt.IsZero())
+	require.Equal(cbor, cborPayload)
+
+	withTimestamp := appendTimestamp(cbor)
+	newAppliedAt, newPayload := splitMigrationValue(withTimestamp)
+	require.False(newAppliedAt.IsZero())
+	require.Equal(cbor, newPayload)
+}
+
+func TestUnmarshalMigrationPayloadBothFormats(t *testing.T) {
+	require, db := require.New(t), ethdb.NewTestDB(t)
+
+	require.NoError(stages.SaveStageProgress(db, stages.Execution, 42))
+	cbor, err := MarshalMigrationPayload(db)
+	require.NoError(err)
+
+	legacyDecoded, err := UnmarshalMigrationPayload(cbor)
+	require.NoError(err)
+	require.Equal(1, len(legacyDecoded))
+
+	newDecoded, err := UnmarshalMigrationPayload(appendTimestamp(cbor))
+	require.NoError(err)
+	require.Equal(legacyDecoded, newDecoded)
+}
+
+func TestStatus(t *testing.T) {
+	require, db := require.New(t), ethdb.NewTestDB(t)
+	migrations = []Migration{
+		{
+			Name: "one",
+			Up: func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+				return OnLoadCommit(db, nil, true)
+			},
+		},
+		{
+			Name: "two",
+			Up: func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+				return OnLoadCommit(db, nil, true)
+			},
+		},
+	}
+
+	migrator := NewMigrator()
+	migrator.Migrations = migrations[:1]
+	err := migrator.Apply(context.Background(), db, "", debug.TestDB() == "mdbx")
+	require.NoError(err)
+
+	migrator.Migrations = migrations
+	statuses, err := migrator.Status(db)
+	require.NoError(err)
+	require.Equal(2, len(statuses))
+
+	require.Equal("one", statuses[0].Name)
+	require.True(statuses[0].Applied)
+	require.False(statuses[0].AppliedAt.IsZero())
+
+	require.Equal("two", statuses[1].Name)
+	require.False(statuses[1].Applied)
+	require.False(statuses[1].InProgress)
+}
+
 func TestValidation(t *testing.T) {
 	require, db := require.New(t), ethdb.NewTestDB(t)
 	migrations = []Migration{
 		{
 			Name: "repeated_name",
-			Up: func(db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+			Up: func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
 				return OnLoadCommit(db, nil, true)
 			},
 		},
 		{
 			Name: "repeated_name",
-			Up: func(db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+			Up: func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
 				return OnLoadCommit(db, nil, true)
 			},
 		},
 	}
 	migrator := NewMigrator()
 	migrator.Migrations = migrations
-	err := migrator.Apply(db, "", debug.TestDB() == "mdbx")
+	err := migrator.Apply(context.Background(), db, "", debug.TestDB() == "mdbx")
 	require.True(errors.Is(err, ErrMigrationNonUniqueName))
 
 	applied, err := AppliedMigrations(db, false)
@@ -182,19 +258,166 @@ func TestValidation(t *testing.T) {
 	require.Equal(0, len(applied))
 }
 
+func TestRollback(t *testing.T) {
+	require, db := require.New(t), ethdb.NewTestDB(t)
+	migrations = []Migration{
+		{
+			Name: "one",
+			Up: func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+				return OnLoadCommit(db, nil, true)
+			},
+			Down: func(db ethdb.Database, tmpdir string, payload []byte, OnLoadCommit etl.LoadCommitHandler) error {
+				return OnLoadCommit(db, nil, true)
+			},
+		},
+		{
+			Name: "two",
+			Up: func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+				return OnLoadCommit(db, nil, true)
+			},
+			Down: func(db ethdb.Database, tmpdir string, payload []byte, OnLoadCommit etl.LoadCommitHandler) error {
+				return OnLoadCommit(db, nil, true)
+			},
+		},
+	}
+
+	migrator := NewMigrator()
+	migrator.Migrations = migrations
+	err := migrator.Apply(context.Background(), db, "", debug.TestDB() == "mdbx")
+	require.NoError(err)
+
+	err = migrator.Rollback(db, "", "one", debug.TestDB() == "mdbx")
+	require.NoError(err)
+
+	applied, err := AppliedMigrations(db, false)
+	require.NoError(err)
+	require.Equal(1, len(applied))
+	_, ok := applied["one"]
+	require.True(ok)
+	_, ok = applied["two"]
+	require.False(ok)
+}
+
+func TestRollbackSkipsUnappliedGap(t *testing.T) {
+	require, db := require.New(t), ethdb.NewTestDB(t)
+	downCalled := map[string]bool{}
+	newMigration := func(name string) Migration {
+		return Migration{
+			Name: name,
+			Up: func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+				return OnLoadCommit(db, nil, true)
+			},
+			Down: func(db ethdb.Database, tmpdir string, payload []byte, OnLoadCommit etl.LoadCommitHandler) error {
+				downCalled[name] = true
+				return OnLoadCommit(db, nil, true)
+			},
+		}
+	}
+	one, two, three := newMigration("one"), newMigration("two"), newMigration("three")
+
+	migrator := NewMigrator()
+	migrator.Migrations = []Migration{one, two, three}
+
+	// Apply only "one" and "three", leaving "two" an unapplied gap, the
+	// way TestWhenNonFirstMigrationAlreadyApplied exercises Apply.
+	require.NoError(db.Put(dbutils.Migrations, []byte("one"), []byte{1}))
+	require.NoError(db.Put(dbutils.Migrations, []byte("three"), []byte{1}))
+
+	require.NoError(migrator.Rollback(db, "", "one", debug.TestDB() == "mdbx"))
+
+	require.False(downCalled["two"], "Down must not run for a migration that was never applied")
+	require.True(downCalled["three"])
+
+	applied, err := AppliedMigrations(db, false)
+	require.NoError(err)
+	_, ok := applied["three"]
+	require.False(ok)
+	_, ok = applied["one"]
+	require.True(ok)
+}
+
+func TestRollbackEmptyTargetUndoesEverything(t *testing.T) {
+	require, db := require.New(t), ethdb.NewTestDB(t)
+	migrations = []Migration{
+		{
+			Name: "one",
+			Up: func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+				return OnLoadCommit(db, nil, true)
+			},
+			Down: func(db ethdb.Database, tmpdir string, payload []byte, OnLoadCommit etl.LoadCommitHandler) error {
+				return OnLoadCommit(db, nil, true)
+			},
+		},
+		{
+			Name: "two",
+			Up: func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+				return OnLoadCommit(db, nil, true)
+			},
+			Down: func(db ethdb.Database, tmpdir string, payload []byte, OnLoadCommit etl.LoadCommitHandler) error {
+				return OnLoadCommit(db, nil, true)
+			},
+		},
+	}
+
+	migrator := NewMigrator()
+	migrator.Migrations = migrations
+	require.NoError(migrator.Apply(context.Background(), db, "", debug.TestDB() == "mdbx"))
+
+	require.NoError(migrator.Rollback(db, "", "", debug.TestDB() == "mdbx"))
+
+	applied, err := AppliedMigrations(db, false)
+	require.NoError(err)
+	require.Equal(0, len(applied))
+}
+
+func TestRollbackIrreversible(t *testing.T) {
+	require, db := require.New(t), ethdb.NewTestDB(t)
+	migrations = []Migration{
+		{
+			Name: "one",
+			Up: func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+				return OnLoadCommit(db, nil, true)
+			},
+			// no Down: irreversible
+		},
+		{
+			Name: "two",
+			Up: func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+				return OnLoadCommit(db, nil, true)
+			},
+			Down: func(db ethdb.Database, tmpdir string, payload []byte, OnLoadCommit etl.LoadCommitHandler) error {
+				return OnLoadCommit(db, nil, true)
+			},
+		},
+	}
+
+	migrator := NewMigrator()
+	migrator.Migrations = migrations
+	err := migrator.Apply(context.Background(), db, "", debug.TestDB() == "mdbx")
+	require.NoError(err)
+
+	err = migrator.Rollback(db, "", "", debug.TestDB() == "mdbx")
+	require.True(errors.Is(err, ErrMigrationIrreversible))
+
+	// nothing should have been undone
+	applied, err := AppliedMigrations(db, false)
+	require.NoError(err)
+	require.Equal(2, len(applied))
+}
+
 func TestCommitCallRequired(t *testing.T) {
 	require, db := require.New(t), ethdb.NewTestDB(t)
 	migrations = []Migration{
 		{
 			Name: "one",
-			Up: func(db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
+			Up: func(ctx context.Context, db ethdb.Database, tmpdir string, progress []byte, OnLoadCommit etl.LoadCommitHandler) error {
 				return nil // don't call OnLoadCommit
 			},
 		},
 	}
 	migrator := NewMigrator()
 	migrator.Migrations = migrations
-	err := migrator.Apply(db, "", debug.TestDB() == "mdbx")
+	err := migrator.Apply(context.Background(), db, "", debug.TestDB() == "mdbx")
 	require.True(errors.Is(err, ErrMigrationCommitNotCalled))
 
 	applied, err := AppliedMigrations(db, false)